@@ -19,14 +19,20 @@ package main
 import (
 	"fmt"
 	"github.com/golang/glog"
+	evsource "github.com/heptiolabs/eventrouter/pkg/source"
 	"github.com/heptiolabs/eventrouter/sinks"
+	"github.com/heptiolabs/eventrouter/status"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/viper"
 	v1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	coreinformers "k8s.io/client-go/informers/core/v1"
+	eventsv1informers "k8s.io/client-go/informers/events/v1"
 	"k8s.io/client-go/kubernetes"
 	corelisters "k8s.io/client-go/listers/core/v1"
+	eventsv1listers "k8s.io/client-go/listers/events/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -89,23 +95,55 @@ type EventRouter struct {
 	// returns true if the event store has been synced
 	eListerSynched cache.InformerSynced
 
-	// event sink
-	// TODO: Determine if we want to support multiple sinks.
-	eSink sinks.EventSinkInterface
+	// source wraps the core/v1 Event informer so it can also be plugged into
+	// a controller-runtime manager as a source.Source; Run uses it directly
+	source *evsource.EventSource
+
+	// store of events.k8s.io/v1 events populated by the shared informer, if
+	// the caller wired one up via NewEventRouter
+	eV1Lister eventsv1listers.EventLister
+
+	// returns true if the events.k8s.io/v1 event store has been synced
+	eV1ListerSynched cache.InformerSynced
+
+	// sourceV1 is the events.k8s.io/v1 counterpart of source, nil unless an
+	// eventsV1Informer was passed to NewEventRouter
+	sourceV1 *evsource.EventSource
+
+	// eSink fans events out to every configured sink route. See
+	// sinks.SinkRouter for the per-sink rules, queues and retry behavior.
+	eSink *sinks.SinkRouter
+
+	// tracker records the last EventStatus pushed for each event, so a
+	// restart's initial LIST doesn't get replayed into the sink and
+	// Prometheus as if every event were new
+	tracker status.Tracker
+
+	// inFlight counts event handler calls currently running, so Run can tell
+	// whether anything is still being processed during graceful shutdown
+	inFlight int64
 }
 
-// NewEventRouter will create a new event router using the input params
-func NewEventRouter(kubeClient kubernetes.Interface, eventsInformer coreinformers.EventInformer) *EventRouter {
+// NewEventRouter will create a new event router using the input params.
+// eventsV1Informer may be nil, in which case only core/v1 Events are watched.
+func NewEventRouter(kubeClient kubernetes.Interface, eventsInformer coreinformers.EventInformer, eventsV1Informer eventsv1informers.EventInformer) *EventRouter {
 	if viper.GetBool("enable-prometheus") {
 		prometheus.MustRegister(kubernetesWarningEventGaugeVec)
 		prometheus.MustRegister(kubernetesNormalEventGaugeVec)
 		prometheus.MustRegister(kubernetesInfoEventGaugeVec)
 		prometheus.MustRegister(kubernetesUnknownEventGaugeVec)
+		prometheus.MustRegister(eventrouterLifecycleEventsTotal)
+	}
+
+	router, err := sinks.NewSinkRouterFromConfig()
+	if err != nil {
+		glog.Fatalf("could not build sink router: %v", err)
 	}
 
 	er := &EventRouter{
 		kubeClient: kubeClient,
-		eSink:      sinks.ManufactureSink(),
+		eSink:      router,
+		tracker:    status.NewTrackerFromConfig(),
 	}
 	//glog.Errorf("new event router")
 	eventsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -116,42 +154,202 @@ func NewEventRouter(kubeClient kubernetes.Interface, eventsInformer coreinformer
 
 	er.eLister = eventsInformer.Lister()
 	er.eListerSynched = eventsInformer.Informer().HasSynced
+	er.source = evsource.New(eventsInformer.Informer())
+
+	if eventsV1Informer != nil {
+		eventsV1Informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    er.addEventV1,
+			UpdateFunc: er.updateEventV1,
+			DeleteFunc: er.deleteEventV1,
+		})
+
+		er.eV1Lister = eventsV1Informer.Lister()
+		er.eV1ListerSynched = eventsV1Informer.Informer().HasSynced
+		er.sourceV1 = evsource.New(eventsV1Informer.Informer())
+	}
 	//glog.Errorf("sync ok")
 	return er
 }
 
-// Run starts the EventRouter/Controller.
+// Run starts the EventRouter/Controller for standalone use. It's built on
+// top of the same source.EventSource that lets an EventRouter be embedded in
+// a controller-runtime manager instead (see pkg/source), so cache-sync
+// behavior is identical either way.
 func (er *EventRouter) Run(stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer glog.Infof("Shutting down EventRouter")
 
 	glog.Infof("Starting EventRouter")
 
-	// here is where we kick the caches into gear
-	if !cache.WaitForCacheSync(stopCh, er.eListerSynched) {
+	if !er.source.WaitForCacheSync(stopCh) {
+		er.emitLifecycleEvent("Warning", "CacheSyncTimeout", "timed out waiting for the core/v1 Event cache to sync")
 		utilruntime.HandleError(fmt.Errorf("timed out waiting for caches to sync"))
 		return
 	}
+	if er.sourceV1 != nil && !er.sourceV1.WaitForCacheSync(stopCh) {
+		er.emitLifecycleEvent("Warning", "CacheSyncTimeout", "timed out waiting for the events.k8s.io/v1 Event cache to sync")
+		utilruntime.HandleError(fmt.Errorf("timed out waiting for events.k8s.io/v1 cache to sync"))
+		return
+	}
+	er.emitLifecycleEvent("Normal", "Started", "eventrouter caches synced and is now watching events")
+
 	<-stopCh
+
+	er.emitLifecycleEvent("Normal", "ShuttingDown", "eventrouter is shutting down")
+	er.waitForInFlight()
 }
 
-// addEvent is called when an event is created, or during the initial list
+// addEvent is called when a core/v1 event is created, or during the initial list
 func (er *EventRouter) addEvent(obj interface{}) {
+	defer er.trackInFlight()()
 	e := obj.(*v1.Event)
-	prometheusEvent(e, false)
-	er.eSink.UpdateEvents(e, nil)
+	ne := normalizeCoreEvent(e)
+	candidate := status.EventStatus{ResourceVersion: e.ResourceVersion, Count: ne.Count, Message: ne.Message, LastObservedTime: ne.LastTimestamp}
+
+	if er.shouldSuppress(ne.UID, candidate) {
+		prometheusResumeEvent(ne)
+		return
+	}
+
+	prometheusEvent(ne, false)
+	er.eSink.UpdateEvents(ne, nil)
+	er.putStatus(ne.UID, candidate)
 }
 
-// updateEvent is called any time there is an update to an existing event
+// updateEvent is called any time there is an update to an existing core/v1 event
 func (er *EventRouter) updateEvent(objOld interface{}, objNew interface{}) {
+	defer er.trackInFlight()()
 	eOld := objOld.(*v1.Event)
 	eNew := objNew.(*v1.Event)
-	prometheusEvent(eNew, false)
-	er.eSink.UpdateEvents(eNew, eOld)
+	neOld := normalizeCoreEvent(eOld)
+	neNew := normalizeCoreEvent(eNew)
+	candidate := status.EventStatus{ResourceVersion: eNew.ResourceVersion, Count: neNew.Count, Message: neNew.Message, LastObservedTime: neNew.LastTimestamp}
+
+	if er.shouldSuppress(neNew.UID, candidate) {
+		prometheusResumeEvent(neNew)
+		return
+	}
+
+	prometheusEvent(neNew, false)
+	er.eSink.UpdateEvents(neNew, neOld)
+	er.putStatus(neNew.UID, candidate)
+}
+
+// addEventV1 is called when an events.k8s.io/v1 event is created, or during
+// the initial list. Unlike addEvent this may represent many prior
+// occurrences folded into Series, so it's normalized and pushed once as a
+// single observation rather than expanded.
+func (er *EventRouter) addEventV1(obj interface{}) {
+	defer er.trackInFlight()()
+	e := obj.(*eventsv1.Event)
+	ne := normalizeEventsV1(e)
+	candidate := status.EventStatus{ResourceVersion: e.ResourceVersion, Count: ne.Count, Message: ne.Message, LastObservedTime: ne.LastTimestamp}
+
+	if er.shouldSuppress(ne.UID, candidate) {
+		prometheusResumeEvent(ne)
+		return
+	}
+
+	prometheusEvent(ne, false)
+	er.eSink.UpdateEvents(ne, nil)
+	er.putStatus(ne.UID, candidate)
+}
+
+// updateEventV1 is called any time there is an update to an existing
+// events.k8s.io/v1 event. A bump to event.Series.Count represents one or
+// more additional occurrences of the same underlying event, so it's
+// expanded into one sink push per observed increment rather than a single
+// overwrite.
+func (er *EventRouter) updateEventV1(objOld interface{}, objNew interface{}) {
+	defer er.trackInFlight()()
+	eOld := objOld.(*eventsv1.Event)
+	eNew := objNew.(*eventsv1.Event)
+
+	finalNew := normalizeEventsV1(eNew)
+	candidate := status.EventStatus{ResourceVersion: eNew.ResourceVersion, Count: finalNew.Count, Message: finalNew.Message, LastObservedTime: finalNew.LastTimestamp}
+	if er.shouldSuppress(finalNew.UID, candidate) {
+		prometheusResumeEvent(finalNew)
+		return
+	}
+
+	delta := seriesCount(eNew) - seriesCount(eOld)
+	if delta <= 0 {
+		neOld := normalizeEventsV1(eOld)
+		prometheusEvent(finalNew, false)
+		er.eSink.UpdateEvents(finalNew, neOld)
+		er.putStatus(finalNew.UID, candidate)
+		return
+	}
+
+	neOld := normalizeEventsV1(eOld)
+	base := seriesCount(eOld)
+	for i := int32(1); i <= delta; i++ {
+		neNew := normalizeEventsV1(eNew)
+		neNew.Count = base + i
+		prometheusEvent(neNew, false)
+		er.eSink.UpdateEvents(neNew, neOld)
+		neOld = neNew
+	}
+	er.putStatus(finalNew.UID, candidate)
+}
+
+// deleteEventV1 should only occur when the system garbage collects events
+// via TTL expiration
+func (er *EventRouter) deleteEventV1(obj interface{}) {
+	e := obj.(*eventsv1.Event)
+	prometheusEvent(normalizeEventsV1(e), true)
+	glog.V(5).Infof("Event Deleted from the system:\n%v", e)
+}
+
+// shouldSuppress reports whether candidate has already been observed (and
+// presumably already pushed to the sink) according to er.tracker, which is
+// the case on the informer's initial LIST after an eventrouter restart.
+func (er *EventRouter) shouldSuppress(uid types.UID, candidate status.EventStatus) bool {
+	prev, ok := er.tracker.Get(uid)
+	return ok && prev.GreaterOrEqual(candidate)
+}
+
+// putStatus records candidate as the last-seen status for uid
+func (er *EventRouter) putStatus(uid types.UID, candidate status.EventStatus) {
+	if err := er.tracker.Put(uid, candidate); err != nil {
+		glog.Warningf("event-status: could not record status for %s: %v", uid, err)
+	}
+}
+
+// prometheusResumeEvent touches the gauge for event without incrementing it.
+// It's used when an event is suppressed as an already-seen replay, so the
+// series still exists for the event but the restart doesn't double-count it.
+func prometheusResumeEvent(event *sinks.NormalizedEvent) {
+	if !viper.GetBool("enable-prometheus") {
+		return
+	}
+
+	var gaugeVec *prometheus.GaugeVec
+	switch event.Type {
+	case "Normal":
+		gaugeVec = kubernetesNormalEventGaugeVec
+	case "Warning":
+		gaugeVec = kubernetesWarningEventGaugeVec
+	case "Info":
+		gaugeVec = kubernetesInfoEventGaugeVec
+	default:
+		gaugeVec = kubernetesUnknownEventGaugeVec
+	}
+
+	if _, err := gaugeVec.GetMetricWithLabelValues(
+		event.InvolvedObject.Kind,
+		event.InvolvedObject.Name,
+		event.InvolvedObject.Namespace,
+		event.Reason,
+		sourceLabel(event),
+		event.Name,
+	); err != nil {
+		glog.Warning(err)
+	}
 }
 
 // prometheusEvent is called when an event is added or updated
-func prometheusEvent(event *v1.Event, shouldDel bool) {
+func prometheusEvent(event *sinks.NormalizedEvent, shouldDel bool) {
 	if !viper.GetBool("enable-prometheus") {
 		return
 	}
@@ -168,8 +366,8 @@ func prometheusEvent(event *v1.Event, shouldDel bool) {
 				event.InvolvedObject.Name,
 				event.InvolvedObject.Namespace,
 				event.Reason,
-				event.Source.Host,
-				event.ObjectMeta.Name,
+				sourceLabel(event),
+				event.Name,
 			)
 		case "Warning":
 			delok = kubernetesWarningEventGaugeVec.DeleteLabelValues(
@@ -177,8 +375,8 @@ func prometheusEvent(event *v1.Event, shouldDel bool) {
 				event.InvolvedObject.Name,
 				event.InvolvedObject.Namespace,
 				event.Reason,
-				event.Source.Host,
-				event.ObjectMeta.Name,
+				sourceLabel(event),
+				event.Name,
 			)
 		case "Info":
 			delok = kubernetesInfoEventGaugeVec.DeleteLabelValues(
@@ -186,8 +384,8 @@ func prometheusEvent(event *v1.Event, shouldDel bool) {
 				event.InvolvedObject.Name,
 				event.InvolvedObject.Namespace,
 				event.Reason,
-				event.Source.Host,
-				event.ObjectMeta.Name,
+				sourceLabel(event),
+				event.Name,
 			)
 		default:
 			delok = kubernetesUnknownEventGaugeVec.DeleteLabelValues(
@@ -195,11 +393,11 @@ func prometheusEvent(event *v1.Event, shouldDel bool) {
 				event.InvolvedObject.Name,
 				event.InvolvedObject.Namespace,
 				event.Reason,
-				event.Source.Host,
-				event.ObjectMeta.Name,
+				sourceLabel(event),
+				event.Name,
 			)
 		}
-		glog.Infof("result: %t del event: %s ", delok, event.ObjectMeta.Name)
+		glog.Infof("result: %t del event: %s ", delok, event.Name)
 		return
 	}
 	switch event.Type {
@@ -209,8 +407,8 @@ func prometheusEvent(event *v1.Event, shouldDel bool) {
 			event.InvolvedObject.Name,
 			event.InvolvedObject.Namespace,
 			event.Reason,
-			event.Source.Host,
-			event.ObjectMeta.Name,
+			sourceLabel(event),
+			event.Name,
 		)
 	case "Warning":
 		gauge, err = kubernetesWarningEventGaugeVec.GetMetricWithLabelValues(
@@ -218,8 +416,8 @@ func prometheusEvent(event *v1.Event, shouldDel bool) {
 			event.InvolvedObject.Name,
 			event.InvolvedObject.Namespace,
 			event.Reason,
-			event.Source.Host,
-			event.ObjectMeta.Name,
+			sourceLabel(event),
+			event.Name,
 		)
 	case "Info":
 		gauge, err = kubernetesInfoEventGaugeVec.GetMetricWithLabelValues(
@@ -227,8 +425,8 @@ func prometheusEvent(event *v1.Event, shouldDel bool) {
 			event.InvolvedObject.Name,
 			event.InvolvedObject.Namespace,
 			event.Reason,
-			event.Source.Host,
-			event.ObjectMeta.Name,
+			sourceLabel(event),
+			event.Name,
 		)
 	default:
 		gauge, err = kubernetesUnknownEventGaugeVec.GetMetricWithLabelValues(
@@ -236,8 +434,8 @@ func prometheusEvent(event *v1.Event, shouldDel bool) {
 			event.InvolvedObject.Name,
 			event.InvolvedObject.Namespace,
 			event.Reason,
-			event.Source.Host,
-			event.ObjectMeta.Name,
+			sourceLabel(event),
+			event.Name,
 		)
 	}
 
@@ -252,8 +450,18 @@ func prometheusEvent(event *v1.Event, shouldDel bool) {
 // deleteEvent should only occur when the system garbage collects events via TTL expiration
 func (er *EventRouter) deleteEvent(obj interface{}) {
 	e := obj.(*v1.Event)
-	prometheusEvent(e, true)
+	prometheusEvent(normalizeCoreEvent(e), true)
 	// NOTE: This should *only* happen on TTL expiration there
 	// is no reason to push this to a sink
 	glog.V(5).Infof("Event Deleted from the system:\n%v", e)
 }
+
+// sourceLabel returns the "source" prometheus label for an event. Core/v1
+// events carry this in Source.Host; events.k8s.io/v1 events have no
+// equivalent field, so ReportingController is used instead.
+func sourceLabel(event *sinks.NormalizedEvent) string {
+	if event.Source.Host != "" {
+		return event.Source.Host
+	}
+	return event.ReportingController
+}
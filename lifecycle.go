@@ -0,0 +1,125 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/heptiolabs/eventrouter/sinks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// eventrouterLifecycleEventsTotal counts synthetic events eventrouter emits
+// about its own lifecycle (start, cache-sync timeout, shutdown, late
+// events), kept separate from the cluster-event gauges in eventrouter.go so a restart
+// storm doesn't get mistaken for a spike in cluster activity. It's a gauge
+// rather than a counter because it's reset to zero on every process start,
+// so a single long-lived counter would conflate "events this run" with
+// "events ever".
+var eventrouterLifecycleEventsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "heptio_eventrouter_lifecycle_events_total",
+	Help: "Count of synthetic lifecycle events emitted by eventrouter about itself, by reason",
+}, []string{"reason"})
+
+// defaultShutdownGracePeriod is used when "shutdown-grace-period" isn't set
+const defaultShutdownGracePeriod = 5 * time.Second
+
+// trackInFlight marks one event handler call as in progress and returns a
+// func to call when it's done, so Run can tell during shutdown whether
+// anything is still being processed.
+func (er *EventRouter) trackInFlight() func() {
+	atomic.AddInt64(&er.inFlight, 1)
+	return func() {
+		atomic.AddInt64(&er.inFlight, -1)
+	}
+}
+
+// waitForInFlight blocks, up to a configurable grace period, for any event
+// handler calls still in flight and any sink-queue work they enqueued
+// (including retries and backoff sleeps) to finish. If work is still
+// outstanding in the last fifth of the grace period, it emits a Warning/
+// LateEvents synthetic event so downstream consumers can see that shutdown
+// cut off in-progress work. Whatever is still outstanding once the grace
+// period elapses is abandoned: the sink queues are closed so their worker
+// goroutines exit rather than leaking.
+func (er *EventRouter) waitForInFlight() {
+	grace := viper.GetDuration("shutdown-grace-period")
+	if grace <= 0 {
+		grace = defaultShutdownGracePeriod
+	}
+	lateThreshold := grace / 5
+	deadline := time.Now().Add(grace)
+	warned := false
+
+	for {
+		remaining := atomic.LoadInt64(&er.inFlight) + er.eSink.Pending()
+		if remaining == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		if !warned && time.Until(deadline) <= lateThreshold {
+			er.emitLifecycleEvent("Warning", "LateEvents", fmt.Sprintf("%d event(s) still processing during the shutdown grace period", remaining))
+			warned = true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	er.eSink.Close(0)
+}
+
+// selfInvolvedObject identifies eventrouter's own pod, read from the
+// downward API env vars operators are expected to set in the pod spec.
+func selfInvolvedObject() v1.ObjectReference {
+	return v1.ObjectReference{
+		Kind:      "Pod",
+		Name:      os.Getenv("POD_NAME"),
+		Namespace: os.Getenv("POD_NAMESPACE"),
+	}
+}
+
+// emitLifecycleEvent pushes a synthetic event about eventrouter's own
+// lifecycle through the same normalization and sink path as real cluster
+// events, tagged with Source.Component="eventrouter" so it's easy to filter.
+func (er *EventRouter) emitLifecycleEvent(eventType, reason, message string) {
+	now := metav1.Now()
+	ne := &sinks.NormalizedEvent{
+		Namespace:      os.Getenv("POD_NAMESPACE"),
+		Name:           fmt.Sprintf("eventrouter.%s.%d", reason, now.UnixNano()),
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Count:          1,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		InvolvedObject: selfInvolvedObject(),
+		Source:         v1.EventSource{Component: "eventrouter"},
+	}
+
+	if viper.GetBool("enable-prometheus") {
+		eventrouterLifecycleEventsTotal.WithLabelValues(reason).Inc()
+	}
+
+	er.eSink.UpdateEvents(ne, nil)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heptiolabs/eventrouter/sinks"
+	"github.com/spf13/viper"
+)
+
+// blockingSink never returns from UpdateEvents until release is closed, so
+// tests can simulate a sink that's still mid-push when shutdown begins.
+type blockingSink struct {
+	release chan struct{}
+}
+
+func (s *blockingSink) UpdateEvents(eNew, eOld *sinks.NormalizedEvent) {
+	<-s.release
+}
+
+func withShutdownGracePeriod(t *testing.T, d time.Duration) {
+	t.Helper()
+	prev := viper.Get("shutdown-grace-period")
+	viper.Set("shutdown-grace-period", d)
+	t.Cleanup(func() { viper.Set("shutdown-grace-period", prev) })
+}
+
+func TestWaitForInFlightReturnsImmediatelyWhenIdle(t *testing.T) {
+	withShutdownGracePeriod(t, time.Second)
+
+	router, err := sinks.NewSinkRouter(nil, false)
+	if err != nil {
+		t.Fatalf("NewSinkRouter() error = %v", err)
+	}
+	er := &EventRouter{eSink: router}
+
+	start := time.Now()
+	er.waitForInFlight()
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("waitForInFlight() took %v with nothing in flight, want it to return almost immediately", elapsed)
+	}
+}
+
+func TestWaitForInFlightGivesUpAfterGracePeriod(t *testing.T) {
+	grace := 100 * time.Millisecond
+	withShutdownGracePeriod(t, grace)
+
+	sink := &blockingSink{release: make(chan struct{})}
+	defer close(sink.release)
+
+	router, err := sinks.NewSinkRouter([]sinks.SinkRoute{{Name: "stuck", Sink: sink}}, false)
+	if err != nil {
+		t.Fatalf("NewSinkRouter() error = %v", err)
+	}
+	er := &EventRouter{eSink: router}
+	router.UpdateEvents(&sinks.NormalizedEvent{Name: "e1"}, nil)
+
+	start := time.Now()
+	er.waitForInFlight()
+	elapsed := time.Since(start)
+
+	if elapsed < grace {
+		t.Errorf("waitForInFlight() returned after %v, want it to wait out the full %v grace period", elapsed, grace)
+	}
+	if elapsed > grace*5 {
+		t.Errorf("waitForInFlight() took %v, want it to give up close to the %v grace period rather than block indefinitely", elapsed, grace)
+	}
+}
+
+// TestWaitForInFlightDoesNotPanicWhenHandlerOutlivesGracePeriod reproduces a
+// handler that's still running (and hasn't called trackInFlight's returned
+// func yet) when the grace period elapses and waitForInFlight closes eSink.
+// That handler then reaching er.eSink.UpdateEvents must not panic.
+func TestWaitForInFlightDoesNotPanicWhenHandlerOutlivesGracePeriod(t *testing.T) {
+	grace := 50 * time.Millisecond
+	withShutdownGracePeriod(t, grace)
+
+	sink := &blockingSink{release: make(chan struct{})}
+	defer close(sink.release)
+
+	router, err := sinks.NewSinkRouter([]sinks.SinkRoute{{Name: "r", Sink: sink}}, false)
+	if err != nil {
+		t.Fatalf("NewSinkRouter() error = %v", err)
+	}
+	er := &EventRouter{eSink: router}
+
+	done := er.trackInFlight()
+	lateDone := make(chan struct{})
+	go func() {
+		defer done()
+		time.Sleep(grace * 2)
+		er.eSink.UpdateEvents(&sinks.NormalizedEvent{Name: "late"}, nil)
+		close(lateDone)
+	}()
+
+	er.waitForInFlight()
+
+	select {
+	case <-lateDone:
+	case <-time.After(time.Second):
+		t.Fatal("handler's late UpdateEvents call never returned")
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/heptiolabs/eventrouter/sinks"
+	v1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// normalizeCoreEvent converts a legacy core/v1 Event into a NormalizedEvent
+func normalizeCoreEvent(e *v1.Event) *sinks.NormalizedEvent {
+	return &sinks.NormalizedEvent{
+		UID:            e.UID,
+		Namespace:      e.Namespace,
+		Name:           e.Name,
+		Reason:         e.Reason,
+		Message:        e.Message,
+		Type:           e.Type,
+		Count:          e.Count,
+		FirstTimestamp: e.FirstTimestamp,
+		LastTimestamp:  e.LastTimestamp,
+		InvolvedObject: e.InvolvedObject,
+		Source:         e.Source,
+		Raw:            e,
+	}
+}
+
+// normalizeEventsV1 converts an events.k8s.io/v1 Event into a NormalizedEvent.
+// A v1 Event folds repeats into Series{Count, LastObservedTime} rather than
+// the core API's Count/FirstTimestamp/LastTimestamp triplet, so in the
+// common case (no Series yet) this is the event's single occurrence.
+func normalizeEventsV1(e *eventsv1.Event) *sinks.NormalizedEvent {
+	ne := &sinks.NormalizedEvent{
+		UID:                 e.UID,
+		Namespace:           e.Namespace,
+		Name:                e.Name,
+		Reason:              e.Reason,
+		Action:              e.Action,
+		Message:             e.Note,
+		Type:                e.Type,
+		InvolvedObject:      e.Regarding,
+		RelatedObject:       e.Related,
+		ReportingController: e.ReportingController,
+		ReportingInstance:   e.ReportingInstance,
+		FirstTimestamp:      metav1.NewTime(e.EventTime.Time),
+		Raw:                 e,
+	}
+
+	if e.Series != nil {
+		ne.Count = e.Series.Count
+		ne.LastTimestamp = metav1.NewTime(e.Series.LastObservedTime.Time)
+	} else {
+		ne.Count = 1
+		ne.LastTimestamp = ne.FirstTimestamp
+	}
+
+	return ne
+}
+
+// seriesCount returns the current repeat count carried by an events.k8s.io/v1
+// Event, treating an Event with no Series as a single occurrence.
+func seriesCount(e *eventsv1.Event) int32 {
+	if e.Series == nil {
+		return 1
+	}
+	return e.Series.Count
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNormalizeEventsV1NoSeries(t *testing.T) {
+	eventTime := metav1.NewMicroTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	e := &eventsv1.Event{
+		Reason:    "Scheduled",
+		Note:      "Successfully assigned pod to node",
+		Type:      "Normal",
+		EventTime: eventTime,
+	}
+
+	ne := normalizeEventsV1(e)
+
+	if ne.Count != 1 {
+		t.Errorf("Count = %d, want 1 for an event with no Series", ne.Count)
+	}
+	if !ne.LastTimestamp.Equal(&ne.FirstTimestamp) {
+		t.Errorf("LastTimestamp = %v, want it to equal FirstTimestamp (%v) when there's no Series", ne.LastTimestamp, ne.FirstTimestamp)
+	}
+	if !ne.FirstTimestamp.Time.Equal(eventTime.Time) {
+		t.Errorf("FirstTimestamp = %v, want %v", ne.FirstTimestamp, eventTime)
+	}
+}
+
+func TestNormalizeEventsV1WithSeries(t *testing.T) {
+	lastObserved := metav1.NewMicroTime(time.Date(2026, 1, 2, 3, 5, 0, 0, time.UTC))
+	e := &eventsv1.Event{
+		Reason: "BackOff",
+		Note:   "Back-off restarting failed container",
+		Type:   "Warning",
+		Series: &eventsv1.EventSeries{
+			Count:            5,
+			LastObservedTime: lastObserved,
+		},
+	}
+
+	ne := normalizeEventsV1(e)
+
+	if ne.Count != 5 {
+		t.Errorf("Count = %d, want 5 from Series.Count", ne.Count)
+	}
+	// LastTimestamp must be a metav1.Time converted from Series.LastObservedTime
+	// (a metav1.MicroTime); assigning the MicroTime directly doesn't compile.
+	if !ne.LastTimestamp.Time.Equal(lastObserved.Time) {
+		t.Errorf("LastTimestamp = %v, want %v", ne.LastTimestamp, lastObserved)
+	}
+}
+
+func TestSeriesCount(t *testing.T) {
+	cases := []struct {
+		name string
+		e    *eventsv1.Event
+		want int32
+	}{
+		{"no series", &eventsv1.Event{}, 1},
+		{"with series", &eventsv1.Event{Series: &eventsv1.EventSeries{Count: 7}}, 7},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := seriesCount(c.e); got != c.want {
+				t.Errorf("seriesCount() = %d, want %d", got, c.want)
+			}
+		})
+	}
+}
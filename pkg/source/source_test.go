@@ -0,0 +1,61 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForSyncSatisfiesSyncingSource(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	factory := informers.NewSharedInformerFactory(client, 0)
+	informer := factory.Core().V1().Events().Informer()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	factory.Start(stop)
+
+	s := New(informer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := s.WaitForSync(ctx); err != nil {
+		t.Errorf("WaitForSync() error = %v, want nil once the informer's cache has synced", err)
+	}
+}
+
+func TestWaitForSyncReturnsErrorWhenContextIsDone(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	factory := informers.NewSharedInformerFactory(client, 0)
+	informer := factory.Core().V1().Events().Informer()
+
+	// Deliberately not calling factory.Start, so the informer never syncs.
+	s := New(informer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := s.WaitForSync(ctx); err == nil {
+		t.Error("WaitForSync() error = nil, want an error when the context is already done before syncing")
+	}
+}
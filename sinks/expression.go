@@ -0,0 +1,130 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sinks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// expressionClause is one "field op value" comparison parsed out of a Rule's
+// Expression string. A full CEL implementation is overkill for the fields
+// available on a NormalizedEvent, so Expression supports "&&"-joined clauses
+// over them instead, e.g. `Message contains "OOMKilled" && Count >= 3`.
+type expressionClause struct {
+	field string
+	op    string
+	value string
+}
+
+// expressionOps is checked in this order, and the first operator found in a
+// clause is the one used. Each is searched for with a space on either side,
+// so ">" never matches inside ">=".
+var expressionOps = []string{">=", "<=", "!=", "==", ">", "<", "contains"}
+
+func parseExpression(expr string) ([]expressionClause, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+
+	var clauses []expressionClause
+	for _, part := range strings.Split(expr, "&&") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		clause, err := parseClause(part)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, clause)
+	}
+	return clauses, nil
+}
+
+func parseClause(part string) (expressionClause, error) {
+	for _, op := range expressionOps {
+		idx := strings.Index(part, " "+op+" ")
+		if idx < 0 {
+			continue
+		}
+		field := strings.TrimSpace(part[:idx])
+		value := strings.Trim(strings.TrimSpace(part[idx+len(op)+2:]), `"'`)
+		return expressionClause{field: field, op: op, value: value}, nil
+	}
+	return expressionClause{}, fmt.Errorf("sinks: could not parse rule expression clause %q", part)
+}
+
+// matches evaluates the clause against event. An unknown field or a
+// non-numeric comparand for a numeric operator is treated as a non-match
+// rather than an error; parseExpression checks neither when the rule is
+// compiled.
+func (c expressionClause) matches(event *NormalizedEvent) bool {
+	actual, ok := expressionFieldValue(event, c.field)
+	if !ok {
+		return false
+	}
+
+	switch c.op {
+	case "==":
+		return actual == c.value
+	case "!=":
+		return actual != c.value
+	case "contains":
+		return strings.Contains(actual, c.value)
+	case ">", ">=", "<", "<=":
+		av, aerr := strconv.ParseFloat(actual, 64)
+		bv, berr := strconv.ParseFloat(c.value, 64)
+		if aerr != nil || berr != nil {
+			return false
+		}
+		switch c.op {
+		case ">":
+			return av > bv
+		case ">=":
+			return av >= bv
+		case "<":
+			return av < bv
+		default:
+			return av <= bv
+		}
+	default:
+		return false
+	}
+}
+
+func expressionFieldValue(event *NormalizedEvent, field string) (string, bool) {
+	switch field {
+	case "Namespace":
+		return event.InvolvedObject.Namespace, true
+	case "Kind":
+		return event.InvolvedObject.Kind, true
+	case "Name":
+		return event.InvolvedObject.Name, true
+	case "Reason":
+		return event.Reason, true
+	case "Type":
+		return event.Type, true
+	case "Message":
+		return event.Message, true
+	case "Count":
+		return strconv.Itoa(int(event.Count)), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sinks
+
+import "github.com/golang/glog"
+
+// GlogSink logs events through glog. It's the default sink, useful mostly
+// for local testing and as a reference implementation of EventSinkInterface.
+type GlogSink struct{}
+
+// NewGlogSink constructs a new GlogSink
+func NewGlogSink() *GlogSink {
+	return &GlogSink{}
+}
+
+// UpdateEvents implements EventSinkInterface
+func (g *GlogSink) UpdateEvents(eNew, eOld *NormalizedEvent) {
+	if eOld == nil {
+		glog.Infof("Add Event: %s %s/%s: %s", eNew.Type, eNew.Namespace, eNew.InvolvedObject.Name, eNew.Message)
+		return
+	}
+	glog.Infof("Update Event: %s %s/%s: %s", eNew.Type, eNew.Namespace, eNew.InvolvedObject.Name, eNew.Message)
+}
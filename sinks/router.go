@@ -0,0 +1,302 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sinks
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultQueueSize  = 1000
+	defaultMaxRetries = 3
+	defaultBackoff    = 100 * time.Millisecond
+)
+
+var (
+	sinkDroppedEventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "heptio_eventrouter_sink_dropped_total",
+		Help: "Total number of events dropped because a sink's queue was full",
+	}, []string{"sink"})
+	sinkRetriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "heptio_eventrouter_sink_retries_total",
+		Help: "Total number of retries attempted while pushing an event to a sink",
+	}, []string{"sink"})
+)
+
+func init() {
+	prometheus.MustRegister(sinkDroppedEventsTotal)
+	prometheus.MustRegister(sinkRetriesTotal)
+}
+
+// SinkRoute pairs a sink with the rules that decide which events reach it.
+// An empty Rules slice matches every event.
+type SinkRoute struct {
+	Name       string
+	Sink       EventSinkInterface
+	Rules      []Rule
+	QueueSize  int
+	MaxRetries int
+}
+
+type sinkJob struct {
+	eNew, eOld *NormalizedEvent
+}
+
+type boundRoute struct {
+	name       string
+	sink       EventSinkInterface
+	rules      []compiledRule
+	maxRetries int
+	queue      chan sinkJob
+
+	// pending counts jobs that have been enqueued but not yet finished
+	// (including retries and backoff sleeps), so Drain can tell whether the
+	// route is still doing work after the informer has stopped delivering.
+	pending int64
+
+	// closeMu guards closed and queue's closedness together, so enqueue
+	// can't race a concurrent Close: sending on a closed channel panics
+	// even inside a select with a default case (the send case is always
+	// considered ready, default or not), so enqueue must see closed go
+	// true before Close actually closes queue, never concurrently with it.
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+// SinkRouter fans an event out to every sink whose rules match it. Each sink
+// has its own bounded, independently-retried worker queue, so a slow or
+// failing sink can't block the informer callback or the other sinks.
+type SinkRouter struct {
+	routes []*boundRoute
+	dryRun bool
+}
+
+// NewSinkRouter builds a SinkRouter from routes and starts one worker
+// goroutine per route. If dryRun is true, routing decisions are logged but
+// never pushed to a sink.
+func NewSinkRouter(routes []SinkRoute, dryRun bool) (*SinkRouter, error) {
+	r := &SinkRouter{dryRun: dryRun}
+
+	for _, rt := range routes {
+		compiled := make([]compiledRule, 0, len(rt.Rules))
+		for _, rule := range rt.Rules {
+			cr, err := compileRule(rule)
+			if err != nil {
+				return nil, err
+			}
+			compiled = append(compiled, cr)
+		}
+
+		queueSize := rt.QueueSize
+		if queueSize <= 0 {
+			queueSize = defaultQueueSize
+		}
+		maxRetries := rt.MaxRetries
+		if maxRetries <= 0 {
+			maxRetries = defaultMaxRetries
+		}
+
+		br := &boundRoute{
+			name:       rt.Name,
+			sink:       rt.Sink,
+			rules:      compiled,
+			maxRetries: maxRetries,
+			queue:      make(chan sinkJob, queueSize),
+		}
+		go br.run()
+		r.routes = append(r.routes, br)
+	}
+
+	return r, nil
+}
+
+// matches reports whether event should be routed to this sink. No rules
+// means "route everything".
+func (br *boundRoute) matches(event *NormalizedEvent) bool {
+	if len(br.rules) == 0 {
+		return true
+	}
+	for _, rule := range br.rules {
+		if rule.Matches(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// run drains the route's queue, retrying each push with a simple backoff
+// before giving up and moving on to the next job.
+func (br *boundRoute) run() {
+	for job := range br.queue {
+		var err error
+		for attempt := 0; attempt <= br.maxRetries; attempt++ {
+			if attempt > 0 {
+				sinkRetriesTotal.WithLabelValues(br.name).Inc()
+				time.Sleep(defaultBackoff * time.Duration(attempt))
+			}
+			if err = pushToSink(br.sink, job.eNew, job.eOld); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			glog.Warningf("sink %s: giving up after %d retries: %v", br.name, br.maxRetries, err)
+		}
+		atomic.AddInt64(&br.pending, -1)
+	}
+}
+
+// enqueue adds job to br's queue, returning false if the route has been
+// closed or its queue is full. Held under closeMu's read lock so it can't
+// race a concurrent Close into sending on a closed channel.
+func (br *boundRoute) enqueue(job sinkJob) bool {
+	br.closeMu.RLock()
+	defer br.closeMu.RUnlock()
+
+	if br.closed {
+		return false
+	}
+	select {
+	case br.queue <- job:
+		atomic.AddInt64(&br.pending, 1)
+		return true
+	default:
+		return false
+	}
+}
+
+// pushToSink calls UpdateEvents, recovering from a panicking sink so one bad
+// sink can't take down the router's worker goroutine. eNew/eOld are adjusted
+// per-sink first: a sink only sees NormalizedEvent.Raw for an
+// events.k8s.io/v1 event if it implements V1NativeSink and opts in.
+func pushToSink(sink EventSinkInterface, eNew, eOld *NormalizedEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sink panicked: %v", r)
+		}
+	}()
+	sink.UpdateEvents(eNew.forSink(sink), eOld.forSink(sink))
+	return nil
+}
+
+// UpdateEvents implements EventSinkInterface, fanning the event out to every
+// route whose rules match it.
+func (r *SinkRouter) UpdateEvents(eNew, eOld *NormalizedEvent) {
+	for _, br := range r.routes {
+		if !br.matches(eNew) {
+			continue
+		}
+
+		if r.dryRun {
+			glog.Infof("dry-run: would route event %s/%s to sink %s", eNew.Namespace, eNew.Name, br.name)
+			continue
+		}
+
+		if !br.enqueue(sinkJob{eNew: eNew, eOld: eOld}) {
+			sinkDroppedEventsTotal.WithLabelValues(br.name).Inc()
+			glog.Warningf("sink %s: queue full or closed, dropping event %s/%s", br.name, eNew.Namespace, eNew.Name)
+		}
+	}
+}
+
+// Pending returns the number of jobs enqueued across all routes that haven't
+// finished yet, including ones currently sleeping between retries.
+func (r *SinkRouter) Pending() int64 {
+	var total int64
+	for _, br := range r.routes {
+		total += atomic.LoadInt64(&br.pending)
+	}
+	return total
+}
+
+// Drain blocks until every route's queue has emptied and its in-flight job
+// (if any) has finished, or until timeout elapses. It reports whether
+// everything drained in time. Callers that need the worker goroutines to
+// actually stop afterwards should follow a successful Drain with Close.
+func (r *SinkRouter) Drain(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if r.Pending() == 0 {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// Close drains every route up to timeout and then closes its queue, so each
+// boundRoute.run goroutine exits once it has processed whatever made it into
+// the channel. It reports whether Drain finished cleanly before timeout.
+// Safe to call even while a producer may still be calling UpdateEvents
+// concurrently (e.g. a slow handler still in flight past a shutdown grace
+// period): closeMu's write lock excludes any enqueue in progress before the
+// queue is actually closed, so a late UpdateEvents is dropped instead of
+// panicking on a send to a closed channel.
+func (r *SinkRouter) Close(timeout time.Duration) bool {
+	drained := r.Drain(timeout)
+	for _, br := range r.routes {
+		br.closeMu.Lock()
+		br.closed = true
+		close(br.queue)
+		br.closeMu.Unlock()
+	}
+	return drained
+}
+
+// NewSinkRouterFromConfig builds a SinkRouter from the "sinkRoutes" section
+// of viper configuration (typically loaded from a YAML file), falling back
+// to a single route wrapping ManufactureSink() with no rules (route
+// everything) when none is configured.
+func NewSinkRouterFromConfig() (*SinkRouter, error) {
+	var configs []struct {
+		Name       string `mapstructure:"name"`
+		Sink       string `mapstructure:"sink"`
+		Rules      []Rule `mapstructure:"rules"`
+		QueueSize  int    `mapstructure:"queueSize"`
+		MaxRetries int    `mapstructure:"maxRetries"`
+	}
+
+	if err := viper.UnmarshalKey("sinkRoutes", &configs); err != nil {
+		return nil, err
+	}
+
+	dryRun := viper.GetBool("sink-dry-run")
+
+	if len(configs) == 0 {
+		return NewSinkRouter([]SinkRoute{{Name: "default", Sink: ManufactureSink()}}, dryRun)
+	}
+
+	routes := make([]SinkRoute, 0, len(configs))
+	for _, c := range configs {
+		routes = append(routes, SinkRoute{
+			Name:       c.Name,
+			Sink:       manufactureNamedSink(c.Sink),
+			Rules:      c.Rules,
+			QueueSize:  c.QueueSize,
+			MaxRetries: c.MaxRetries,
+		})
+	}
+	return NewSinkRouter(routes, dryRun)
+}
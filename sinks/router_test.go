@@ -0,0 +1,118 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sinks
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakySink panics on its first failUntil calls to UpdateEvents, then
+// succeeds, so tests can exercise boundRoute's retry/backoff behavior
+// without a real external sink.
+type flakySink struct {
+	failUntil int32
+	calls     int32
+}
+
+func (s *flakySink) UpdateEvents(eNew, eOld *NormalizedEvent) {
+	n := atomic.AddInt32(&s.calls, 1)
+	if n <= s.failUntil {
+		panic("flakySink: simulated failure")
+	}
+}
+
+func TestBoundRouteRetriesUntilSuccess(t *testing.T) {
+	sink := &flakySink{failUntil: 2}
+	router, err := NewSinkRouter([]SinkRoute{{Name: "flaky", Sink: sink, MaxRetries: 3}}, false)
+	if err != nil {
+		t.Fatalf("NewSinkRouter() error = %v", err)
+	}
+
+	router.UpdateEvents(&NormalizedEvent{Name: "e1"}, nil)
+
+	if !router.Drain(time.Second) {
+		t.Fatal("Drain() = false, want the route to finish within the timeout")
+	}
+	if got := atomic.LoadInt32(&sink.calls); got != 3 {
+		t.Errorf("sink.calls = %d, want 3 (2 failures + 1 success)", got)
+	}
+}
+
+func TestBoundRouteGivesUpAfterMaxRetries(t *testing.T) {
+	sink := &flakySink{failUntil: 100}
+	router, err := NewSinkRouter([]SinkRoute{{Name: "flaky", Sink: sink, MaxRetries: 2}}, false)
+	if err != nil {
+		t.Fatalf("NewSinkRouter() error = %v", err)
+	}
+
+	router.UpdateEvents(&NormalizedEvent{Name: "e1"}, nil)
+
+	if !router.Drain(time.Second) {
+		t.Fatal("Drain() = false, want the route to give up and finish within the timeout")
+	}
+	if got := atomic.LoadInt32(&sink.calls); got != 3 {
+		t.Errorf("sink.calls = %d, want 3 (1 initial attempt + 2 retries)", got)
+	}
+	if got := router.Pending(); got != 0 {
+		t.Errorf("Pending() = %d, want 0 after the job finished (even though it was given up on)", got)
+	}
+}
+
+func TestSinkRouterPendingAndClose(t *testing.T) {
+	sink := &flakySink{}
+	router, err := NewSinkRouter([]SinkRoute{{Name: "ok", Sink: sink}}, false)
+	if err != nil {
+		t.Fatalf("NewSinkRouter() error = %v", err)
+	}
+
+	router.UpdateEvents(&NormalizedEvent{Name: "e1"}, nil)
+
+	if !router.Close(time.Second) {
+		t.Fatal("Close() = false, want the single queued job to drain within the timeout")
+	}
+	if got := router.Pending(); got != 0 {
+		t.Errorf("Pending() = %d, want 0 after Close", got)
+	}
+}
+
+// TestUpdateEventsDoesNotPanicConcurrentWithClose reproduces a late producer
+// (e.g. a slow informer handler still running past the shutdown grace
+// period) calling UpdateEvents at the same time Close is closing the
+// queue. It must drop the event rather than panic on a send to a closed
+// channel.
+func TestUpdateEventsDoesNotPanicConcurrentWithClose(t *testing.T) {
+	sink := &flakySink{}
+	router, err := NewSinkRouter([]SinkRoute{{Name: "ok", Sink: sink}}, false)
+	if err != nil {
+		t.Fatalf("NewSinkRouter() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			router.UpdateEvents(&NormalizedEvent{Name: "late"}, nil)
+		}(i)
+	}
+
+	router.Close(time.Second)
+	wg.Wait()
+}
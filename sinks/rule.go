@@ -0,0 +1,112 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sinks
+
+import (
+	"path"
+	"regexp"
+)
+
+// Rule describes when an event should be routed to a sink. All non-empty
+// fields must match for the rule to apply; an empty field is a wildcard.
+type Rule struct {
+	// NamespaceGlob is matched against InvolvedObject.Namespace using
+	// shell-style globbing (see path.Match), e.g. "kube-*".
+	NamespaceGlob string `mapstructure:"namespace" yaml:"namespace"`
+	// KindPattern is a regexp matched against InvolvedObject.Kind.
+	KindPattern string `mapstructure:"kind" yaml:"kind"`
+	// ReasonPattern is a regexp matched against Reason.
+	ReasonPattern string `mapstructure:"reason" yaml:"reason"`
+	// Types restricts matches to these event types (e.g. "Normal", "Warning").
+	Types []string `mapstructure:"types" yaml:"types"`
+	// MinCount requires Count to be at least this value.
+	MinCount int32 `mapstructure:"minCount" yaml:"minCount"`
+	// Expression is an optional "&&"-joined set of "field op value"
+	// comparisons over the event (see expression.go), for matches the fixed
+	// fields above can't express, e.g. `Message contains "OOMKilled"`.
+	Expression string `mapstructure:"expression" yaml:"expression"`
+}
+
+// compiledRule is a Rule with its regexps and expression pre-compiled, so
+// matching doesn't pay parsing cost per event.
+type compiledRule struct {
+	Rule
+	kindRe     *regexp.Regexp
+	reasonRe   *regexp.Regexp
+	expression []expressionClause
+}
+
+func compileRule(r Rule) (compiledRule, error) {
+	cr := compiledRule{Rule: r}
+	if r.KindPattern != "" {
+		re, err := regexp.Compile(r.KindPattern)
+		if err != nil {
+			return cr, err
+		}
+		cr.kindRe = re
+	}
+	if r.ReasonPattern != "" {
+		re, err := regexp.Compile(r.ReasonPattern)
+		if err != nil {
+			return cr, err
+		}
+		cr.reasonRe = re
+	}
+	expr, err := parseExpression(r.Expression)
+	if err != nil {
+		return cr, err
+	}
+	cr.expression = expr
+	return cr, nil
+}
+
+// Matches reports whether event satisfies every non-empty field of the rule
+func (cr compiledRule) Matches(event *NormalizedEvent) bool {
+	if cr.NamespaceGlob != "" {
+		ok, err := path.Match(cr.NamespaceGlob, event.InvolvedObject.Namespace)
+		if err != nil || !ok {
+			return false
+		}
+	}
+	if cr.kindRe != nil && !cr.kindRe.MatchString(event.InvolvedObject.Kind) {
+		return false
+	}
+	if cr.reasonRe != nil && !cr.reasonRe.MatchString(event.Reason) {
+		return false
+	}
+	if len(cr.Types) > 0 && !containsString(cr.Types, event.Type) {
+		return false
+	}
+	if cr.MinCount > 0 && event.Count < cr.MinCount {
+		return false
+	}
+	for _, clause := range cr.expression {
+		if !clause.matches(event) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sinks
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func testEvent() *NormalizedEvent {
+	return &NormalizedEvent{
+		Reason:  "BackOff",
+		Message: "Back-off restarting failed container: OOMKilled",
+		Type:    "Warning",
+		Count:   3,
+		InvolvedObject: v1.ObjectReference{
+			Namespace: "kube-system",
+			Kind:      "Pod",
+			Name:      "coredns-abc123",
+		},
+	}
+}
+
+func TestCompiledRuleMatches(t *testing.T) {
+	cases := []struct {
+		name string
+		rule Rule
+		want bool
+	}{
+		{"no fields matches everything", Rule{}, true},
+		{"namespace glob matches", Rule{NamespaceGlob: "kube-*"}, true},
+		{"namespace glob doesn't match", Rule{NamespaceGlob: "default"}, false},
+		{"kind regexp matches", Rule{KindPattern: "^Pod$"}, true},
+		{"kind regexp doesn't match", Rule{KindPattern: "^Node$"}, false},
+		{"reason regexp matches", Rule{ReasonPattern: "Back.*"}, true},
+		{"type enum matches", Rule{Types: []string{"Normal", "Warning"}}, true},
+		{"type enum doesn't match", Rule{Types: []string{"Normal"}}, false},
+		{"minCount satisfied", Rule{MinCount: 3}, true},
+		{"minCount not satisfied", Rule{MinCount: 4}, false},
+		{"expression matches", Rule{Expression: `Message contains "OOMKilled"`}, true},
+		{"expression doesn't match", Rule{Expression: `Message contains "Evicted"`}, false},
+		{"expression combined with fixed field", Rule{NamespaceGlob: "kube-*", Expression: "Count >= 3"}, true},
+		{"expression combined with fixed field fails on fixed field", Rule{NamespaceGlob: "default", Expression: "Count >= 3"}, false},
+	}
+
+	event := testEvent()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cr, err := compileRule(c.rule)
+			if err != nil {
+				t.Fatalf("compileRule() error = %v", err)
+			}
+			if got := cr.Matches(event); got != c.want {
+				t.Errorf("Matches() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestCompileRuleInvalidExpression(t *testing.T) {
+	if _, err := compileRule(Rule{Expression: "Message unknownop foo"}); err == nil {
+		t.Fatal("compileRule() error = nil, want error for an unparsable expression")
+	}
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sinks
+
+import (
+	"github.com/golang/glog"
+	"github.com/spf13/viper"
+)
+
+// ManufactureSink will manufacture a sink according to viper configuration
+func ManufactureSink() EventSinkInterface {
+	return manufactureNamedSink(viper.GetString("sink"))
+}
+
+// manufactureNamedSink builds a sink by name, independent of the top-level
+// "sink" viper key. It backs both ManufactureSink and the per-route sinks
+// configured for a SinkRouter.
+func manufactureNamedSink(name string) EventSinkInterface {
+	switch name {
+	case "glog", "":
+		return NewGlogSink()
+	default:
+		glog.Warningf("Unknown sink %q, falling back to glog", name)
+		return NewGlogSink()
+	}
+}
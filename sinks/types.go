@@ -0,0 +1,91 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sinks
+
+import (
+	v1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NormalizedEvent is a source-agnostic view of a Kubernetes event. Both the
+// legacy core/v1 Event and the events.k8s.io/v1 Event are collapsed into this
+// shape before they reach a sink, so sinks never need to know which API
+// produced the event they're being handed.
+type NormalizedEvent struct {
+	UID       types.UID
+	Namespace string
+	Name      string
+
+	Reason  string
+	Action  string
+	Message string
+	Type    string
+
+	Count          int32
+	FirstTimestamp metav1.Time
+	LastTimestamp  metav1.Time
+
+	InvolvedObject v1.ObjectReference
+	RelatedObject  *v1.ObjectReference
+
+	Source              v1.EventSource
+	ReportingController string
+	ReportingInstance   string
+
+	// Raw holds the original API object (*v1.Event or *eventsv1.Event) that
+	// this NormalizedEvent was built from, for sinks that want to emit the
+	// native payload instead of (or in addition to) the normalized shape.
+	Raw interface{}
+}
+
+// EventSinkInterface is the interface used to shuttle normalized events to
+// an output destination, such as logging or an external database
+type EventSinkInterface interface {
+	UpdateEvents(eNew, eOld *NormalizedEvent)
+}
+
+// V1NativeSink may be implemented by an EventSinkInterface that wants
+// NormalizedEvent.Raw populated with the events.k8s.io/v1 object whenever the
+// source event came from that API, rather than having callers strip it down
+// to the normalized fields only.
+type V1NativeSink interface {
+	PreferV1Native() bool
+}
+
+// forSink returns ne as-is if sink opted into v1-native payloads via
+// V1NativeSink, or a shallow copy with Raw cleared otherwise. Only
+// events.k8s.io/v1 payloads are stripped; Raw *v1.Event (the legacy API) has
+// no "native" opt-in concept and is left alone.
+func (ne *NormalizedEvent) forSink(sink EventSinkInterface) *NormalizedEvent {
+	if ne == nil {
+		return nil
+	}
+	if _, isEventsV1 := ne.Raw.(*eventsv1.Event); !isEventsV1 {
+		return ne
+	}
+
+	prefer, ok := sink.(V1NativeSink)
+	if ok && prefer.PreferV1Native() {
+		return ne
+	}
+
+	stripped := *ne
+	stripped.Raw = nil
+	return &stripped
+}
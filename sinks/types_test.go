@@ -0,0 +1,74 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sinks
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+)
+
+// v1NativeStubSink implements V1NativeSink so tests can exercise the
+// opt-in branch of forSink; prefer controls what PreferV1Native reports.
+type v1NativeStubSink struct {
+	prefer bool
+}
+
+func (s *v1NativeStubSink) UpdateEvents(eNew, eOld *NormalizedEvent) {}
+func (s *v1NativeStubSink) PreferV1Native() bool                     { return s.prefer }
+
+func TestForSinkKeepsRawForV1NativeSinkThatOptsIn(t *testing.T) {
+	ne := &NormalizedEvent{Name: "e1", Raw: &eventsv1.Event{}}
+
+	got := ne.forSink(&v1NativeStubSink{prefer: true})
+
+	if got.Raw == nil {
+		t.Error("forSink() stripped Raw even though the sink implements V1NativeSink and opted in")
+	}
+}
+
+func TestForSinkStripsRawForV1NativeSinkThatDeclines(t *testing.T) {
+	ne := &NormalizedEvent{Name: "e1", Raw: &eventsv1.Event{}}
+
+	got := ne.forSink(&v1NativeStubSink{prefer: false})
+
+	if got.Raw != nil {
+		t.Error("forSink() kept Raw even though the sink declined via PreferV1Native() = false")
+	}
+}
+
+func TestForSinkStripsRawForSinkWithoutOptIn(t *testing.T) {
+	ne := &NormalizedEvent{Name: "e1", Raw: &eventsv1.Event{}}
+
+	got := ne.forSink(&flakySink{})
+
+	if got.Raw != nil {
+		t.Error("forSink() kept Raw for a sink that doesn't implement V1NativeSink at all")
+	}
+}
+
+func TestForSinkLeavesCoreV1RawAlone(t *testing.T) {
+	raw := &v1.Event{}
+	ne := &NormalizedEvent{Name: "e1", Raw: raw}
+
+	got := ne.forSink(&flakySink{})
+
+	if got.Raw != raw {
+		t.Error("forSink() stripped Raw for a non-events.k8s.io/v1 payload, which has no opt-in concept")
+	}
+}
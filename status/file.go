@@ -0,0 +1,88 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// FileTracker is a Tracker persisted as a single JSON file. It's the
+// simplest store that survives a restart, and is meant for single-replica
+// deployments that have a writable local volume.
+type FileTracker struct {
+	path string
+
+	mu sync.Mutex
+	m  map[types.UID]EventStatus
+}
+
+// NewFileTracker constructs a FileTracker backed by path, loading any state
+// already present. A missing file is treated as an empty tracker rather
+// than an error, since that's the expected state on first run.
+func NewFileTracker(path string) *FileTracker {
+	t := &FileTracker{path: path, m: make(map[types.UID]EventStatus)}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			glog.Warningf("event-status: could not read %s: %v", path, err)
+		}
+		return t
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&t.m); err != nil {
+		glog.Warningf("event-status: could not decode %s: %v", path, err)
+		t.m = make(map[types.UID]EventStatus)
+	}
+	return t
+}
+
+// Get implements Tracker
+func (t *FileTracker) Get(uid types.UID) (EventStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.m[uid]
+	return s, ok
+}
+
+// Put implements Tracker. Each call rewrites the whole file; event status
+// churn is low-volume relative to event volume, so this isn't batched.
+func (t *FileTracker) Put(uid types.UID, s EventStatus) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.m[uid] = s
+
+	tmp := t.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(t.m); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
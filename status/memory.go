@@ -0,0 +1,52 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// MemoryTracker is a Tracker backed by a plain map. It doesn't survive a
+// restart, so it's the default when no persistent store is configured, and
+// it's useful as a stub in tests.
+type MemoryTracker struct {
+	mu sync.RWMutex
+	m  map[types.UID]EventStatus
+}
+
+// NewMemoryTracker constructs an empty MemoryTracker
+func NewMemoryTracker() *MemoryTracker {
+	return &MemoryTracker{m: make(map[types.UID]EventStatus)}
+}
+
+// Get implements Tracker
+func (t *MemoryTracker) Get(uid types.UID) (EventStatus, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	s, ok := t.m[uid]
+	return s, ok
+}
+
+// Put implements Tracker
+func (t *MemoryTracker) Put(uid types.UID, s EventStatus) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.m[uid] = s
+	return nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package status tracks the last-seen state of each event eventrouter has
+// pushed to a sink, so that a restart doesn't cause the informer's initial
+// LIST to be replayed into sinks and Prometheus counters as if every event
+// in the cluster were new.
+package status
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// EventStatus is the last-seen state of a single event.
+type EventStatus struct {
+	ResourceVersion  string      `json:"resourceVersion"`
+	Count            int32       `json:"count"`
+	Message          string      `json:"message"`
+	LastObservedTime metav1.Time `json:"lastObservedTime"`
+}
+
+// GreaterOrEqual reports whether s represents the same or a later
+// observation of an event than other. Count is the primary signal, since
+// core/v1 Events and events.k8s.io/v1 Series both only ever increase it, but
+// a Series can also update in place without bumping Count (e.g. a new
+// Message/Note on the same occurrence), so a same-Count status is only
+// considered caught-up if its Message also matches.
+func (s EventStatus) GreaterOrEqual(other EventStatus) bool {
+	if s.Count != other.Count {
+		return s.Count > other.Count
+	}
+	return s.Message == other.Message
+}
+
+// Tracker records the last EventStatus pushed for an event, keyed by UID.
+type Tracker interface {
+	// Get returns the last recorded status for uid, and whether one exists.
+	Get(uid types.UID) (EventStatus, bool)
+	// Put records status for uid.
+	Put(uid types.UID, status EventStatus) error
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import "testing"
+
+func TestEventStatusGreaterOrEqual(t *testing.T) {
+	cases := []struct {
+		name  string
+		s     EventStatus
+		other EventStatus
+		want  bool
+	}{
+		{
+			name:  "higher count wins regardless of message",
+			s:     EventStatus{Count: 3, Message: "old"},
+			other: EventStatus{Count: 2, Message: "new"},
+			want:  true,
+		},
+		{
+			name:  "lower count loses regardless of message",
+			s:     EventStatus{Count: 1, Message: "same"},
+			other: EventStatus{Count: 2, Message: "same"},
+			want:  false,
+		},
+		{
+			name:  "same count and same message is caught up",
+			s:     EventStatus{Count: 4, Message: "steady state"},
+			other: EventStatus{Count: 4, Message: "steady state"},
+			want:  true,
+		},
+		{
+			name:  "same count but changed message is not caught up",
+			s:     EventStatus{Count: 4, Message: "new note"},
+			other: EventStatus{Count: 4, Message: "old note"},
+			want:  false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.s.GreaterOrEqual(c.other); got != c.want {
+				t.Errorf("GreaterOrEqual() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
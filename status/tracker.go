@@ -0,0 +1,42 @@
+/*
+Copyright 2017 Heptio Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"github.com/golang/glog"
+	"github.com/spf13/viper"
+)
+
+// NewTrackerFromConfig builds a Tracker according to viper configuration,
+// mirroring sinks.ManufactureSink. Supported values for "event-status-store"
+// are "memory" (the default) and "file", which is backed by the path in
+// "event-status-file".
+func NewTrackerFromConfig() Tracker {
+	switch viper.GetString("event-status-store") {
+	case "file":
+		path := viper.GetString("event-status-file")
+		if path == "" {
+			path = "eventrouter-status.json"
+		}
+		return NewFileTracker(path)
+	case "memory", "":
+		return NewMemoryTracker()
+	default:
+		glog.Warningf("Unknown event-status-store %q, falling back to memory", viper.GetString("event-status-store"))
+		return NewMemoryTracker()
+	}
+}